@@ -1,9 +1,16 @@
 package build
 
 import (
+	"archive/tar"
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/distribution/reference"
 	"github.com/docker/docker/api/types/backend"
@@ -12,8 +19,10 @@ import (
 	"github.com/docker/docker/builder"
 	buildkit "github.com/docker/docker/builder/builder-next"
 	daemonevents "github.com/docker/docker/daemon/events"
+	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/pkg/stringid"
+	bkclient "github.com/moby/buildkit/client"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
 )
@@ -59,8 +68,58 @@ func (b *Backend) Build(ctx context.Context, config backend.BuildConfig) (string
 		return "", err
 	}
 
-	var buildResult *builder.Result
+	if config.Source != nil {
+		wrapped, hash, err := hashDockerfile(config.Source, options.Dockerfile)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to read build context")
+		}
+		config.Source = wrapped
+		config.DockerfileHash = hash
+	}
+
+	buildID := config.BuildID
+	driverName := "classic"
 	if useBuildKit {
+		driverName = "buildkit"
+	}
+	b.eventsService.Log(events.ActionStart, events.BuilderEventType, events.Actor{
+		ID: buildID,
+		Attributes: map[string]string{
+			"tags":           strings.Join(tags, ","),
+			"dockerfileHash": config.DockerfileHash,
+			"driver":         driverName,
+		},
+	})
+
+	imageID, err := b.build(ctx, config, options, tags, useBuildKit, buildID)
+	if err != nil {
+		b.eventsService.Log(events.ActionFail, events.BuilderEventType, events.Actor{
+			ID: buildID,
+			Attributes: map[string]string{
+				"error":      err.Error(),
+				"errorClass": errorClass(err),
+			},
+		})
+		return "", err
+	}
+
+	b.eventsService.Log(events.ActionFinish, events.BuilderEventType, events.Actor{
+		ID:         buildID,
+		Attributes: map[string]string{"imageID": imageID},
+	})
+	return imageID, nil
+}
+
+func (b *Backend) build(ctx context.Context, config backend.BuildConfig, options build.ImageBuildOptions, tags []string, useBuildKit bool, buildID string) (string, error) {
+	var (
+		buildResult *builder.Result
+		err         error
+	)
+	if useBuildKit {
+		statusCh := make(chan *bkclient.SolveStatus)
+		config.StatusChan = statusCh
+		go b.drainSolveStatus(buildID, statusCh)
+		config.CacheImports, config.CacheExports = toSolveCacheOptions(options.CacheOptions)
 		buildResult, err = b.buildkit.Build(ctx, config)
 		if err != nil {
 			return "", err
@@ -91,14 +150,179 @@ func (b *Backend) Build(ctx context.Context, config backend.BuildConfig) (string
 	if imageID != "" && !useBuildKit {
 		stdout := config.ProgressWriter.StdoutFormatter
 		_, _ = fmt.Fprintf(stdout, "Successfully built %s\n", stringid.TruncateID(imageID))
-		err = tagImages(ctx, b.imageComponent, config.ProgressWriter.StdoutFormatter, image.ID(imageID), tags)
+		if err = tagImages(ctx, b.imageComponent, config.ProgressWriter.StdoutFormatter, image.ID(imageID), tags); err != nil {
+			return "", err
+		}
+	}
+
+	if imageID != "" {
+		b.eventsService.Log(events.ActionImageProduced, events.BuilderEventType, events.Actor{
+			ID: buildID,
+			Attributes: map[string]string{
+				"imageID": imageID,
+				"tags":    strings.Join(tags, ","),
+				"squash":  strconv.FormatBool(options.Squash),
+			},
+		})
+	}
+
+	if useBuildKit {
+		b.logCacheEvents(buildID, config.Options.CacheOptions)
+	}
+
+	return imageID, nil
+}
+
+// toSolveCacheOptions translates the cache import and export entries a
+// build requested into the cache-transport entries BuildKit's solve
+// request expects. A CacheOptionsEntry's Mode only applies to exports, and
+// BuildKit has no dedicated field for it: it is folded into Attrs["mode"],
+// mirroring how "docker buildx build --cache-to type=registry,mode=max"
+// is itself translated.
+func toSolveCacheOptions(opts build.CacheOptions) (imports, exports []bkclient.CacheOptionsEntry) {
+	for _, imp := range opts.Imports {
+		imports = append(imports, bkclient.CacheOptionsEntry{Type: imp.Type, Attrs: imp.Attrs})
+	}
+	for _, exp := range opts.Exports {
+		attrs := exp.Attrs
+		if exp.Mode != "" {
+			attrs = make(map[string]string, len(exp.Attrs)+1)
+			for k, v := range exp.Attrs {
+				attrs[k] = v
+			}
+			attrs["mode"] = string(exp.Mode)
+		}
+		exports = append(exports, bkclient.CacheOptionsEntry{Type: exp.Type, Attrs: attrs})
+	}
+	return imports, exports
+}
+
+// toPruneInfo translates the filters and storage cap a caller requested
+// into the bkclient.PruneInfo the BuildKit driver's prune expects, folding
+// each filter key's values into the "key=value" form BuildKit's own
+// filter parser accepts.
+func toPruneInfo(opts build.CachePruneOptions) bkclient.PruneInfo {
+	var filters []string
+	for key, values := range opts.Filters {
+		if len(values) == 0 {
+			filters = append(filters, key)
+			continue
+		}
+		for _, v := range values {
+			filters = append(filters, key+"="+v)
+		}
+	}
+	return bkclient.PruneInfo{
+		Filter:    filters,
+		All:       opts.All,
+		KeepBytes: opts.KeepStorage,
+	}
+}
+
+// logCacheEvents emits a builder event for each configured cache import
+// and export once a BuildKit build they applied to has completed. It is a
+// no-op when opts is the zero value.
+func (b *Backend) logCacheEvents(buildID string, opts build.CacheOptions) {
+	for _, imp := range opts.Imports {
+		b.eventsService.Log(events.ActionCacheImport, events.BuilderEventType, events.Actor{
+			ID:         buildID,
+			Attributes: map[string]string{"type": imp.Type},
+		})
+	}
+	for _, exp := range opts.Exports {
+		b.eventsService.Log(events.ActionCacheExport, events.BuilderEventType, events.Actor{
+			ID:         buildID,
+			Attributes: map[string]string{"type": exp.Type, "mode": string(exp.Mode)},
+		})
+	}
+}
+
+// drainSolveStatus reads the BuildKit solve status the driver reports on
+// ch as the build progresses, translates each newly completed vertex into
+// a stage-complete builder event, and returns once the driver closes ch at
+// the end of the solve.
+func (b *Backend) drainSolveStatus(buildID string, ch <-chan *bkclient.SolveStatus) {
+	for status := range ch {
+		for _, ev := range translateVertexStageEvents(status) {
+			b.LogStageEvent(buildID, ev.Stage, ev.Elapsed, ev.CacheHitRatio)
+		}
+	}
+}
+
+// translateVertexStageEvents converts the vertices a BuildKit solve status
+// reports as newly completed into build.StageEvents. A vertex corresponds
+// to a single Dockerfile stage (or instruction); only vertices with both a
+// Started and Completed time are finished, so in-progress or pending
+// vertices are skipped.
+func translateVertexStageEvents(status *bkclient.SolveStatus) []build.StageEvent {
+	var stageEvents []build.StageEvent
+	for _, v := range status.Vertexes {
+		if v.Started == nil || v.Completed == nil {
+			continue
+		}
+		cacheHitRatio := 0.0
+		if v.Cached {
+			cacheHitRatio = 1.0
+		}
+		stageEvents = append(stageEvents, build.StageEvent{
+			Stage:         v.Name,
+			Elapsed:       v.Completed.Sub(*v.Started),
+			CacheHitRatio: cacheHitRatio,
+		})
 	}
-	return imageID, err
+	return stageEvents
 }
 
-// PruneCache removes all cached build sources
+// LogStageEvent emits a stage-complete builder event for buildID. For
+// BuildKit builds, drainSolveStatus calls it for each vertex completion it
+// translates from the driver's solve status, so subscribers on the /events
+// stream see build progress without Backend needing to parse stdout.
+func (b *Backend) LogStageEvent(buildID, stage string, elapsed time.Duration, cacheHitRatio float64) {
+	b.eventsService.Log(events.ActionStageComplete, events.BuilderEventType, events.Actor{
+		ID: buildID,
+		Attributes: map[string]string{
+			"stage":         stage,
+			"elapsed":       elapsed.String(),
+			"cacheHitRatio": strconv.FormatFloat(cacheHitRatio, 'f', 2, 64),
+		},
+	})
+}
+
+// CacheInfo returns the records currently held in BuildKit's build cache,
+// so a UI can list cache entries before calling PruneCache. It returns
+// nil, nil when the backend is not running BuildKit.
+//
+// CacheRecords is assumed on b.buildkit the same way Build, Prune, Cancel
+// and RegisterGRPC already are: builder-next isn't vendored into this
+// checkout, so its method set can't be checked here.
+func (b *Backend) CacheInfo(ctx context.Context) ([]build.CacheRecord, error) {
+	if b.buildkit == nil {
+		return nil, nil
+	}
+
+	records, err := b.buildkit.CacheRecords(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list build cache")
+	}
+
+	out := make([]build.CacheRecord, 0, len(records))
+	for _, r := range records {
+		out = append(out, build.CacheRecord{
+			ID:          r.ID,
+			Parents:     r.Parents,
+			Size:        r.Size,
+			LastUsedAt:  r.LastUsedAt,
+			Description: r.Description,
+		})
+	}
+	return out, nil
+}
+
+// PruneCache removes cached build sources. opts.Filters and
+// opts.KeepStorage, when set, target the prune to a subset of the cache
+// instead of discarding all of it.
 func (b *Backend) PruneCache(ctx context.Context, opts build.CachePruneOptions) (*build.CachePruneReport, error) {
-	buildCacheSize, cacheIDs, err := b.buildkit.Prune(ctx, opts)
+	buildCacheSize, cacheIDs, err := b.buildkit.Prune(ctx, toPruneInfo(opts))
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to prune build cache")
 	}
@@ -112,8 +336,82 @@ func (b *Backend) PruneCache(ctx context.Context, opts build.CachePruneOptions)
 
 // Cancel the build by ID
 func (b *Backend) Cancel(ctx context.Context, id string) error {
-	// Call the Cancel method of BuildKit with the context and ID
-	return b.buildkit.Cancel(ctx, id)
+	if err := b.buildkit.Cancel(ctx, id); err != nil {
+		return err
+	}
+	b.eventsService.Log(events.ActionCancel, events.BuilderEventType, events.Actor{ID: id})
+	return nil
+}
+
+// hashDockerfile buffers src, the raw build context tar, into memory so it
+// can be read twice: once here to hash the entry at dockerfilePath, and
+// once more by the returned ReadCloser, which the actual build consumes in
+// place of src. It returns the hex-encoded sha256 digest of the
+// Dockerfile's content, or "" if dockerfilePath isn't found in the
+// context.
+func hashDockerfile(src io.ReadCloser, dockerfilePath string) (io.ReadCloser, string, error) {
+	defer src.Close()
+
+	raw, err := io.ReadAll(src)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var hash string
+	tr := tar.NewReader(bytes.NewReader(raw))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Not a well-formed tar: report no hash rather than failing
+			// the build over it.
+			break
+		}
+		if hdr.Name == dockerfilePath {
+			hasher := sha256.New()
+			if _, err := io.Copy(hasher, tr); err != nil {
+				return nil, "", err
+			}
+			hash = hex.EncodeToString(hasher.Sum(nil))
+			break
+		}
+	}
+
+	return io.NopCloser(bytes.NewReader(raw)), hash, nil
+}
+
+// errorClass returns a short, stable classification for err drawn from the
+// errdefs category it satisfies (for example "not-found" or
+// "unavailable"), for use in the "fail" event's errorClass attribute. An
+// HTTP status code is meaningless to a non-HTTP subscriber of the /events
+// stream; this is the same classification errdefs itself uses to pick one.
+func errorClass(err error) string {
+	switch {
+	case errdefs.IsNotFound(err):
+		return "not-found"
+	case errdefs.IsInvalidParameter(err):
+		return "invalid-parameter"
+	case errdefs.IsConflict(err):
+		return "conflict"
+	case errdefs.IsForbidden(err):
+		return "forbidden"
+	case errdefs.IsUnauthorized(err):
+		return "unauthorized"
+	case errdefs.IsUnavailable(err):
+		return "unavailable"
+	case errdefs.IsCancelled(err):
+		return "cancelled"
+	case errdefs.IsDeadline(err):
+		return "deadline"
+	case errdefs.IsDataLoss(err):
+		return "data-loss"
+	case errdefs.IsSystem(err):
+		return "system"
+	default:
+		return "unknown"
+	}
 }
 
 // squashBuild merges the image layers into a single layer.
@@ -0,0 +1,53 @@
+package backend // import "github.com/docker/docker/api/types/backend"
+
+import (
+	"io"
+
+	"github.com/docker/docker/api/types/build"
+	buildkit "github.com/moby/buildkit/client"
+)
+
+// AuxEmitter emits auxiliary, out-of-band JSON progress messages on a
+// build's progress stream.
+type AuxEmitter interface {
+	Emit(string, interface{}) error
+}
+
+// ProgressWriter is the collection of outputs a build reports its
+// progress to.
+type ProgressWriter struct {
+	StdoutFormatter io.Writer
+	StderrFormatter io.Writer
+	AuxFormatter    AuxEmitter
+}
+
+// BuildConfig is the configuration for a single build, as passed to
+// Backend.Build.
+type BuildConfig struct {
+	Source         io.ReadCloser
+	ProgressWriter ProgressWriter
+	Options        build.ImageBuildOptions
+
+	// BuildID identifies this build across its lifetime. Backend.Build
+	// correlates the builder events it emits with it, and it is the
+	// identifier Backend.Cancel expects.
+	BuildID string
+
+	// DockerfileHash is the digest of the resolved Dockerfile content.
+	// When Source is set, Backend.Build computes it from the Dockerfile
+	// entry in the build context, replacing any value the caller set.
+	DockerfileHash string
+
+	// CacheImports and CacheExports are Options.CacheOptions translated
+	// into BuildKit's own cache-transport entries. Backend.Build fills
+	// these in before handing config to the BuildKit driver.
+	CacheImports []buildkit.CacheOptionsEntry
+	CacheExports []buildkit.CacheOptionsEntry
+
+	// StatusChan, when set, is where the BuildKit driver reports solve
+	// status as the build progresses. Backend.Build creates this channel
+	// and owns the consumer side, translating each newly completed
+	// vertex into a stage-complete builder event; the driver is expected
+	// to close it once the solve finishes.
+	StatusChan chan *buildkit.SolveStatus
+}
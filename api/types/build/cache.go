@@ -0,0 +1,43 @@
+package build // import "github.com/docker/docker/api/types/build"
+
+import "time"
+
+// CacheMode controls how much of a build stage BuildKit keeps when
+// exporting it to a cache destination. "min" keeps only the layers needed
+// to reproduce the final image; "max" additionally keeps intermediate
+// layers from earlier stages so they can be reused by later builds that
+// only share part of the Dockerfile.
+type CacheMode string
+
+// Cache modes accepted by a CacheOptionsEntry's Mode field.
+const (
+	CacheModeMin CacheMode = "min"
+	CacheModeMax CacheMode = "max"
+)
+
+// CacheOptionsEntry describes a single cache import source or export
+// destination for a build, mirroring BuildKit's own cache-transport
+// options (for example "registry", "local", "inline", "gha", or "s3").
+type CacheOptionsEntry struct {
+	Type  string
+	Attrs map[string]string
+	// Mode only applies to exports; it is ignored for imports.
+	Mode CacheMode
+}
+
+// CacheOptions collects the cache import and export entries configured
+// for a single build.
+type CacheOptions struct {
+	Imports []CacheOptionsEntry
+	Exports []CacheOptionsEntry
+}
+
+// CacheRecord describes a single entry in BuildKit's build cache, as
+// reported by a worker and surfaced through Backend.CacheInfo.
+type CacheRecord struct {
+	ID          string
+	Parents     []string
+	Size        int64
+	LastUsedAt  *time.Time
+	Description string
+}
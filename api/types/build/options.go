@@ -0,0 +1,33 @@
+package build // import "github.com/docker/docker/api/types/build"
+
+import "time"
+
+// BuilderVersion indicates which builder backend produced, or should
+// produce, a build.
+type BuilderVersion string
+
+// Supported builder versions.
+const (
+	BuilderV1       BuilderVersion = "1"
+	BuilderBuildKit BuilderVersion = "2"
+)
+
+// ImageBuildOptions holds the configuration for a single image build.
+type ImageBuildOptions struct {
+	Tags       []string
+	Dockerfile string
+	Squash     bool
+	Version    BuilderVersion
+
+	// CacheOptions configures the BuildKit cache import and export
+	// sources for this build.
+	CacheOptions CacheOptions
+}
+
+// StageEvent reports a single Dockerfile stage's completion, translated
+// from a BuildKit solve status vertex by the build backend.
+type StageEvent struct {
+	Stage         string
+	Elapsed       time.Duration
+	CacheHitRatio float64
+}
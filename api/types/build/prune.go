@@ -0,0 +1,21 @@
+package build // import "github.com/docker/docker/api/types/build"
+
+// CachePruneOptions holds the parameters to prune the build cache.
+type CachePruneOptions struct {
+	All bool
+	// Filters narrows the prune to cache records matching all of the
+	// given filter keys/values (for example "until", "type"), the same
+	// filter keys accepted by `docker builder prune`.
+	Filters map[string][]string
+	// KeepStorage caps the cache size, in bytes, the prune should bring
+	// the cache down to. Zero means no cap: prune everything matched by
+	// Filters/All.
+	KeepStorage int64
+}
+
+// CachePruneReport contains the response for Engine API:
+// POST "/build/prune"
+type CachePruneReport struct {
+	CachesDeleted  []string
+	SpaceReclaimed uint64
+}
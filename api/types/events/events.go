@@ -0,0 +1,32 @@
+package events // import "github.com/docker/docker/api/types/events"
+
+// Type is the event type, such as what resource kind generated the event.
+type Type string
+
+// Event types the builder publishes.
+const (
+	BuilderEventType Type = "builder"
+)
+
+// Action is the type of event that occurred, for example "start" or
+// "prune".
+type Action string
+
+// Actions published by the build backend.
+const (
+	ActionStart         Action = "start"
+	ActionFinish        Action = "finish"
+	ActionFail          Action = "fail"
+	ActionCancel        Action = "cancel"
+	ActionPrune         Action = "prune"
+	ActionStageComplete Action = "stage-complete"
+	ActionImageProduced Action = "image-produced"
+	ActionCacheImport   Action = "cache-import"
+	ActionCacheExport   Action = "cache-export"
+)
+
+// Actor describes something that generates events, like a builder.
+type Actor struct {
+	ID         string
+	Attributes map[string]string
+}
@@ -6,6 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/containerd/containerd/v2/core/remotes/docker"
 	remoteerrors "github.com/containerd/containerd/v2/core/remotes/errors"
@@ -13,12 +16,65 @@ import (
 	"github.com/containerd/log"
 )
 
+// RateLimit captures the rate-limit accounting a registry reported
+// alongside a response, such as Docker Hub's RateLimit-Limit and
+// RateLimit-Remaining headers.
+type RateLimit struct {
+	Limit     int
+	Remaining int
+}
+
+// RegistryError wraps a translated registry API error together with any
+// retry and rate-limit metadata the registry provided, so pull/push retry
+// loops can honor server-provided backoff instead of a fixed schedule.
+type RegistryError struct {
+	Code       string
+	Message    string
+	RetryAfter time.Duration
+	RateLimit  *RateLimit
+
+	err error
+}
+
+func (e *RegistryError) Error() string {
+	return e.Message
+}
+
+func (e *RegistryError) Unwrap() error {
+	return e.err
+}
+
+// RetryAfter returns the retry-after duration a registry reported for err,
+// if any.
+func RetryAfter(err error) (time.Duration, bool) {
+	var regErr *RegistryError
+	if errors.As(err, &regErr) && regErr.RetryAfter > 0 {
+		return regErr.RetryAfter, true
+	}
+	return 0, false
+}
+
+// IsRateLimited reports whether err is, or wraps, a rate-limit response
+// from the registry.
+func IsRateLimited(err error) bool {
+	var regErr *RegistryError
+	if errors.As(err, &regErr) {
+		return cerrdefs.IsResourceExhausted(regErr.err)
+	}
+	return cerrdefs.IsResourceExhausted(err)
+}
+
 func translateRegistryError(ctx context.Context, err error) error {
 	// Check for registry specific error
 	var derrs docker.Errors
+	var headers http.Header
 	if !errors.As(err, &derrs) {
 		var remoteErr remoteerrors.ErrUnexpectedStatus
 		if errors.As(err, &remoteErr) {
+			// ErrUnexpectedStatus carries the response headers the
+			// registry sent alongside the body, which is where
+			// Retry-After and Docker Hub's RateLimit-* headers live.
+			headers = remoteErr.Header
 			if jerr := json.Unmarshal(remoteErr.Body, &derrs); jerr != nil {
 				log.G(ctx).WithError(derrs).Debug("unable to unmarshal registry error")
 				return fmt.Errorf("%w: %w", cerrdefs.ErrUnknown, err)
@@ -47,6 +103,7 @@ func translateRegistryError(ctx context.Context, err error) error {
 			}
 		}
 	}
+
 	var errs []error
 	for _, err := range derrs {
 		var derr docker.Error
@@ -73,7 +130,17 @@ func translateRegistryError(ctx context.Context, err error) error {
 			case docker.ErrorCodeUnavailable:
 				err = cerrdefs.ErrUnavailable.WithMessage(message)
 			case docker.ErrorCodeTooManyRequests:
-				err = cerrdefs.ErrResourceExhausted.WithMessage(message)
+				retryAfter, _ := retryAfterFromDetail(derr.Detail)
+				if retryAfter == 0 {
+					retryAfter, _ = retryAfterFromHeaders(headers)
+				}
+				err = &RegistryError{
+					Code:       string(derr.Code),
+					Message:    message,
+					RetryAfter: retryAfter,
+					RateLimit:  rateLimitFromHeaders(headers),
+					err:        cerrdefs.ErrResourceExhausted.WithMessage(message),
+				}
 			default:
 				err = cerrdefs.ErrUnknown.WithMessage(message)
 			}
@@ -92,3 +159,76 @@ func translateRegistryError(ctx context.Context, err error) error {
 	}
 	return fmt.Errorf("error from registry: %w", err)
 }
+
+// retryAfterFromDetail looks for a retry_after_seconds field in a docker.Error's
+// Detail, the shape some registries use to report their backoff window in
+// the response body rather than a Retry-After header.
+func retryAfterFromDetail(detail interface{}) (time.Duration, bool) {
+	m, ok := detail.(map[string]interface{})
+	if !ok {
+		return 0, false
+	}
+	raw, ok := m["retry_after_seconds"]
+	if !ok {
+		return 0, false
+	}
+	switch v := raw.(type) {
+	case float64:
+		return time.Duration(v) * time.Second, true
+	case string:
+		if secs, err := strconv.Atoi(v); err == nil {
+			return time.Duration(secs) * time.Second, true
+		}
+	}
+	return 0, false
+}
+
+// retryAfterFromHeaders parses the standard Retry-After header, which may
+// be either a number of seconds or an HTTP-date.
+func retryAfterFromHeaders(h http.Header) (time.Duration, bool) {
+	if h == nil {
+		return 0, false
+	}
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t), true
+	}
+	return 0, false
+}
+
+// rateLimitFromHeaders parses Docker Hub's RateLimit-Limit and
+// RateLimit-Remaining headers. It returns nil if neither is present.
+func rateLimitFromHeaders(h http.Header) *RateLimit {
+	if h == nil {
+		return nil
+	}
+	limit, hasLimit := headerInt(h, "RateLimit-Limit")
+	remaining, hasRemaining := headerInt(h, "RateLimit-Remaining")
+	if !hasLimit && !hasRemaining {
+		return nil
+	}
+	return &RateLimit{Limit: limit, Remaining: remaining}
+}
+
+func headerInt(h http.Header, key string) (int, bool) {
+	v := h.Get(key)
+	if v == "" {
+		return 0, false
+	}
+	// Docker Hub's rate-limit headers are of the form "100;w=21600"; only
+	// the leading count is of interest here.
+	if i := strings.IndexByte(v, ';'); i >= 0 {
+		v = v[:i]
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
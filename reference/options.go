@@ -0,0 +1,37 @@
+package reference
+
+// driver selects the storage backend used by a reference Store returned
+// from NewReferenceStore.
+type driver int
+
+const (
+	driverJSONFile driver = iota
+	driverBoltDB
+)
+
+type storeConfig struct {
+	driver driver
+}
+
+// Option configures the Store returned by NewReferenceStore.
+type Option func(*storeConfig)
+
+// WithJSONFile selects the legacy reference store backend, which
+// serializes the entire set of repositories to a single JSON file on every
+// write. This is the default when no Option is given.
+func WithJSONFile() Option {
+	return func(c *storeConfig) {
+		c.driver = driverJSONFile
+	}
+}
+
+// WithBoltDB selects a bbolt-backed reference store. Unlike the JSON-file
+// backend, it writes each repository to its own bucket and does per-key
+// writes in a transaction, so hosts with large tag inventories (for
+// example CI registries or mirror caches with tens of thousands of tags)
+// avoid rewriting the whole store on every AddTag or AddDigest.
+func WithBoltDB() Option {
+	return func(c *storeConfig) {
+		c.driver = driverBoltDB
+	}
+}
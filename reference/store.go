@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
 
 	"github.com/distribution/reference"
@@ -18,6 +19,16 @@ import (
 // store.
 var ErrDoesNotExist notFoundError = "reference does not exist"
 
+// ErrAmbiguous is returned by Resolve when a short image ID prefix matches
+// more than one image.
+var ErrAmbiguous ambiguousError = "multiple IDs match the provided prefix"
+
+type ambiguousError string
+
+func (e ambiguousError) Error() string {
+	return string(e)
+}
+
 // An Association is a tuple associating a reference with an image ID.
 type Association struct {
 	Ref reference.Named
@@ -32,6 +43,13 @@ type Store interface {
 	AddDigest(ref reference.Canonical, id digest.Digest, force bool) error
 	Remove(ref reference.Named) (bool, error)
 	lookup(ref reference.Named) (digest.Digest, error)
+
+	// Resolve disambiguates refOrID, which may be an image ID (full or a
+	// short prefix), a tag, or a digest reference, to the image ID it maps
+	// to. When resolution went through a name or digest reference, that
+	// reference is also returned; it is nil when refOrID resolved directly
+	// to an image ID.
+	Resolve(refOrID string) (digest.Digest, reference.Named, error)
 }
 
 type refStore struct {
@@ -61,9 +79,26 @@ func (a lexicalAssociations) Less(i, j int) bool {
 	return a[i].Ref.String() < a[j].Ref.String()
 }
 
-// NewReferenceStore creates a new reference store, tied to a file path where
-// the set of references are serialized in JSON format.
-func NewReferenceStore(jsonPath string) (Store, error) {
+// NewReferenceStore creates a new reference store at path, using the
+// backing driver selected by opts. With no options, it defaults to
+// WithJSONFile, preserving the historical single-file behavior.
+func NewReferenceStore(path string, opts ...Option) (Store, error) {
+	cfg := storeConfig{driver: driverJSONFile}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	switch cfg.driver {
+	case driverBoltDB:
+		return newBoltReferenceStore(path)
+	default:
+		return newJSONReferenceStore(path)
+	}
+}
+
+// newJSONReferenceStore creates a reference store backed by a single JSON
+// file at jsonPath.
+func newJSONReferenceStore(jsonPath string) (Store, error) {
 	abspath, err := filepath.Abs(jsonPath)
 	if err != nil {
 		return nil, err
@@ -177,9 +212,9 @@ func (store *refStore) addReference(ref reference.Named, id digest.Digest, force
 	return store.save()
 }
 
-// Delete deletes a reference from the store. It returns true if a deletion
+// Remove deletes a reference from the store. It returns true if a deletion
 // happened, or false otherwise.
-func (store *refStore) Delete(ref reference.Named) (bool, error) {
+func (store *refStore) Remove(ref reference.Named) (bool, error) {
 	ref, err := favorDigest(ref)
 	if err != nil {
 		return false, err
@@ -251,6 +286,92 @@ func (store *refStore) Get(ref reference.Named) (digest.Digest, error) {
 	return id, nil
 }
 
+// Resolve disambiguates refOrID, trying in turn: a raw image ID (digest),
+// a name reference resolved the same way as Get (canonical references take
+// precedence over tagged ones), a tagged reference matched against the
+// name component of any known reference, and finally a short-ID prefix
+// match across every known image ID. It returns ErrDoesNotExist if none of
+// these match, and ErrAmbiguous if a short-ID prefix matches more than one
+// image.
+func (store *refStore) Resolve(refOrID string) (digest.Digest, reference.Named, error) {
+	if id, err := digest.Parse(refOrID); err == nil {
+		store.mu.RLock()
+		_, exists := store.referencesByIDCache[id]
+		store.mu.RUnlock()
+		if exists {
+			return id, nil, nil
+		}
+	}
+
+	if ref, err := reference.ParseAnyReference(refOrID); err == nil {
+		if canonical, ok := ref.(reference.Canonical); ok {
+			if id, err := store.Get(canonical); err == nil {
+				return id, canonical, nil
+			}
+		}
+
+		if named, err := reference.ParseNormalizedNamed(refOrID); err == nil {
+			if id, err := store.Get(named); err == nil {
+				return id, named, nil
+			}
+
+			if tagged, ok := named.(reference.NamedTagged); ok {
+				if id, matched, ok := store.lookupByNameAndTag(tagged); ok {
+					return id, matched, nil
+				}
+			}
+		}
+	}
+
+	return store.resolveIDPrefix(refOrID)
+}
+
+// lookupByNameAndTag walks referencesByIDCache for a stored reference whose
+// familiar name and tag match tagged. This covers repositories that were
+// recorded under a different, but equivalent, normalized form than the one
+// reference.ParseNormalizedNamed produced for the lookup in Resolve.
+func (store *refStore) lookupByNameAndTag(tagged reference.NamedTagged) (digest.Digest, reference.Named, bool) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	name := reference.FamiliarName(tagged)
+	for id, refs := range store.referencesByIDCache {
+		for _, candidate := range refs {
+			candidateTagged, ok := candidate.(reference.NamedTagged)
+			if ok && reference.FamiliarName(candidateTagged) == name && candidateTagged.Tag() == tagged.Tag() {
+				return id, candidate, true
+			}
+		}
+	}
+	return "", nil, false
+}
+
+// resolveIDPrefix performs a short-ID prefix match against every known
+// image ID.
+func (store *refStore) resolveIDPrefix(prefix string) (digest.Digest, reference.Named, error) {
+	if prefix == "" {
+		return "", nil, ErrDoesNotExist
+	}
+
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var match digest.Digest
+	for id := range store.referencesByIDCache {
+		if strings.HasPrefix(id.Encoded(), prefix) || strings.HasPrefix(id.String(), prefix) {
+			if match != "" && match != id {
+				return "", nil, errors.WithStack(ErrAmbiguous)
+			}
+			match = id
+		}
+	}
+
+	if match == "" {
+		return "", nil, ErrDoesNotExist
+	}
+	return match, nil, nil
+}
+
 // References returns a slice of references to the given ID. The slice
 // will be nil if there are no references to this ID.
 func (store *refStore) References(id digest.Digest) []reference.Named {
@@ -0,0 +1,83 @@
+package reference
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/distribution/reference"
+	"github.com/opencontainers/go-digest"
+)
+
+// benchStores returns one Store per driver, rooted in its own temp
+// directory, so the same workload can be run against each backend.
+func benchStores(b *testing.B) map[string]Store {
+	b.Helper()
+
+	jsonStore, err := NewReferenceStore(filepath.Join(b.TempDir(), "repositories.json"), WithJSONFile())
+	if err != nil {
+		b.Fatalf("failed to create JSON reference store: %v", err)
+	}
+
+	boltStore, err := NewReferenceStore(filepath.Join(b.TempDir(), "repositories.db"), WithBoltDB())
+	if err != nil {
+		b.Fatalf("failed to create bolt reference store: %v", err)
+	}
+
+	return map[string]Store{
+		"json": jsonStore,
+		"bolt": boltStore,
+	}
+}
+
+func BenchmarkAddTag(b *testing.B) {
+	for _, n := range []int{100, 1000, 10000} {
+		for name, store := range benchStores(b) {
+			b.Run(fmt.Sprintf("%s/%d-tags", name, n), func(b *testing.B) {
+				refs := make([]reference.Named, n)
+				for i := range refs {
+					ref, err := reference.ParseNormalizedNamed(fmt.Sprintf("example.com/bench/repo-%d:latest", i))
+					if err != nil {
+						b.Fatal(err)
+					}
+					refs[i] = ref
+				}
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					ref := refs[i%n]
+					id := digest.FromString(ref.String())
+					if err := store.AddTag(ref, id, true); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkReferences(b *testing.B) {
+	for _, n := range []int{100, 1000, 10000} {
+		for name, store := range benchStores(b) {
+			b.Run(fmt.Sprintf("%s/%d-tags", name, n), func(b *testing.B) {
+				ids := make([]digest.Digest, n)
+				for i := 0; i < n; i++ {
+					ref, err := reference.ParseNormalizedNamed(fmt.Sprintf("example.com/bench/repo-%d:latest", i))
+					if err != nil {
+						b.Fatal(err)
+					}
+					id := digest.FromString(ref.String())
+					ids[i] = id
+					if err := store.AddTag(ref, id, true); err != nil {
+						b.Fatal(err)
+					}
+				}
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					store.References(ids[i%n])
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,535 @@
+package reference
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// repositoriesBucket holds one nested bucket per repository name. Within a
+// repository bucket, keys are familiar reference strings and values are
+// the raw bytes of the image ID digest they point to.
+var repositoriesBucket = []byte("repositories")
+
+// idsBucket holds one nested bucket per image ID (keyed by the digest's
+// string form). Within an ID bucket, keys are the familiar reference
+// strings that point to that ID; this is the on-disk counterpart of
+// refStore's referencesByIDCache and lets References(id) and Resolve avoid
+// scanning every repository bucket.
+var idsBucket = []byte("ids")
+
+// metaBucket holds store-level bookkeeping, such as whether the one-shot
+// JSON migration has already run.
+var metaBucket = []byte("meta")
+
+// migratedFromJSONKey, once present in metaBucket, marks that
+// migrateFromJSON has already imported repositories.json into this store.
+var migratedFromJSONKey = []byte("migrated-from-json")
+
+// boltStore is a bbolt-backed implementation of Store. It exists for hosts
+// with large tag inventories where refStore's whole-file JSON rewrite on
+// every AddTag/AddDigest/Remove becomes a bottleneck: each write here is a
+// per-key bolt transaction instead of a full marshal of every repository.
+type boltStore struct {
+	db *bolt.DB
+
+	mu                  sync.RWMutex
+	referencesByIDCache map[digest.Digest]map[string]reference.Named
+}
+
+// newBoltReferenceStore opens (creating if necessary) a bbolt-backed
+// reference store at path, migrating any existing JSON-file store
+// (repositories.json, alongside path) found on first open.
+func newBoltReferenceStore(path string) (Store, error) {
+	abspath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(abspath), 0o700); err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(abspath, 0o600, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open bolt reference store")
+	}
+
+	store := &boltStore{
+		db:                  db,
+		referencesByIDCache: make(map[digest.Digest]map[string]reference.Named),
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(repositoriesBucket); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists(idsBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(metaBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := store.warmCache(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := store.migrateFromJSON(filepath.Join(filepath.Dir(abspath), "repositories.json")); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// warmCache populates referencesByIDCache from the on-disk idsBucket so
+// that References and the prefix-matching path in Resolve do not need to
+// touch bolt on every call.
+func (s *boltStore) warmCache() error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		root := tx.Bucket(idsBucket)
+		return root.ForEach(func(idKey, v []byte) error {
+			if v != nil {
+				// Not a nested bucket; shouldn't happen in this bucket.
+				return nil
+			}
+			id := digest.Digest(idKey)
+			idBucket := root.Bucket(idKey)
+			return idBucket.ForEach(func(refStrKey, _ []byte) error {
+				ref, err := reference.ParseNormalizedNamed(string(refStrKey))
+				if err != nil {
+					// Should never happen; skip rather than fail startup.
+					return nil
+				}
+				if s.referencesByIDCache[id] == nil {
+					s.referencesByIDCache[id] = make(map[string]reference.Named)
+				}
+				s.referencesByIDCache[id][string(refStrKey)] = ref
+				return nil
+			})
+		})
+	})
+}
+
+// AddTag adds a tag reference to the store. If force is set to true,
+// existing references can be overwritten. This only works for tags, not
+// digests.
+func (s *boltStore) AddTag(ref reference.Named, id digest.Digest, force bool) error {
+	if _, isCanonical := ref.(reference.Canonical); isCanonical {
+		return errors.WithStack(invalidTagError("refusing to create a tag with a digest reference"))
+	}
+	return s.addReference(reference.TagNameOnly(ref), id, force)
+}
+
+// AddDigest adds a digest reference to the store.
+func (s *boltStore) AddDigest(ref reference.Canonical, id digest.Digest, force bool) error {
+	return s.addReference(ref, id, force)
+}
+
+func (s *boltStore) addReference(ref reference.Named, id digest.Digest, force bool) error {
+	ref, err := favorDigest(ref)
+	if err != nil {
+		return err
+	}
+
+	refName := reference.FamiliarName(ref)
+	refStr := reference.FamiliarString(ref)
+
+	if refName == string(digest.Canonical) {
+		return errors.WithStack(invalidTagError("refusing to create an ambiguous tag using digest algorithm as name"))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var oldID digest.Digest
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		repoBucket, err := tx.Bucket(repositoriesBucket).CreateBucketIfNotExists([]byte(refName))
+		if err != nil {
+			return err
+		}
+
+		if raw := repoBucket.Get([]byte(refStr)); raw != nil {
+			oldID = digest.Digest(raw)
+			if oldID == id {
+				return nil
+			}
+
+			if digested, isDigest := ref.(reference.Canonical); isDigest {
+				return errors.WithStack(conflictingTagError("cannot overwrite digest " + digested.Digest().String()))
+			}
+			if !force {
+				return errors.WithStack(
+					conflictingTagError(
+						fmt.Sprintf("tag %s is already set to image %s, use the force option to replace it", refStr, oldID),
+					),
+				)
+			}
+			if err := unindexRef(tx, oldID, refStr); err != nil {
+				return err
+			}
+		} else {
+			oldID = ""
+		}
+
+		if err := repoBucket.Put([]byte(refStr), []byte(id)); err != nil {
+			return err
+		}
+		return indexRef(tx, id, refStr)
+	})
+	if err != nil || oldID == id {
+		return err
+	}
+
+	if oldID != "" {
+		if refs := s.referencesByIDCache[oldID]; refs != nil {
+			delete(refs, refStr)
+			if len(refs) == 0 {
+				delete(s.referencesByIDCache, oldID)
+			}
+		}
+	}
+	if s.referencesByIDCache[id] == nil {
+		s.referencesByIDCache[id] = make(map[string]reference.Named)
+	}
+	s.referencesByIDCache[id][refStr] = ref
+
+	return nil
+}
+
+// Remove deletes a reference from the store. It returns true if a deletion
+// happened, or false otherwise.
+func (s *boltStore) Remove(ref reference.Named) (bool, error) {
+	ref, err := favorDigest(ref)
+	if err != nil {
+		return false, err
+	}
+	ref = reference.TagNameOnly(ref)
+
+	refName := reference.FamiliarName(ref)
+	refStr := reference.FamiliarString(ref)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var id digest.Digest
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		repoBucket := tx.Bucket(repositoriesBucket).Bucket([]byte(refName))
+		if repoBucket == nil {
+			return ErrDoesNotExist
+		}
+
+		raw := repoBucket.Get([]byte(refStr))
+		if raw == nil {
+			return ErrDoesNotExist
+		}
+		id = digest.Digest(raw)
+
+		if err := repoBucket.Delete([]byte(refStr)); err != nil {
+			return err
+		}
+		if isEmpty(repoBucket) {
+			if err := tx.Bucket(repositoriesBucket).DeleteBucket([]byte(refName)); err != nil {
+				return err
+			}
+		}
+		return unindexRef(tx, id, refStr)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if refs := s.referencesByIDCache[id]; refs != nil {
+		delete(refs, refStr)
+		if len(refs) == 0 {
+			delete(s.referencesByIDCache, id)
+		}
+	}
+
+	return true, nil
+}
+
+// Get retrieves the image ID for ref.
+func (s *boltStore) Get(ref reference.Named) (digest.Digest, error) {
+	return s.lookup(ref)
+}
+
+func (s *boltStore) lookup(ref reference.Named) (digest.Digest, error) {
+	if canonical, ok := ref.(reference.Canonical); ok {
+		if _, ok := ref.(reference.Tagged); ok {
+			var err error
+			ref, err = reference.WithDigest(reference.TrimNamed(canonical), canonical.Digest())
+			if err != nil {
+				return "", err
+			}
+		}
+	} else {
+		ref = reference.TagNameOnly(ref)
+	}
+
+	refName := reference.FamiliarName(ref)
+	refStr := reference.FamiliarString(ref)
+
+	var id digest.Digest
+	err := s.db.View(func(tx *bolt.Tx) error {
+		repoBucket := tx.Bucket(repositoriesBucket).Bucket([]byte(refName))
+		if repoBucket == nil {
+			return ErrDoesNotExist
+		}
+		raw := repoBucket.Get([]byte(refStr))
+		if raw == nil {
+			return ErrDoesNotExist
+		}
+		id = digest.Digest(raw)
+		return nil
+	})
+	return id, err
+}
+
+// References returns a slice of references to the given ID. The slice will
+// be nil if there are no references to this ID.
+func (s *boltStore) References(id digest.Digest) []reference.Named {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var references []reference.Named
+	for _, ref := range s.referencesByIDCache[id] {
+		references = append(references, ref)
+	}
+	sort.Sort(lexicalRefs(references))
+	return references
+}
+
+// ReferencesByName returns the references for a given repository name. If
+// there are no references known for this repository name, ReferencesByName
+// returns nil.
+func (s *boltStore) ReferencesByName(ref reference.Named) []Association {
+	refName := reference.FamiliarName(ref)
+
+	var associations []Association
+	err := s.db.View(func(tx *bolt.Tx) error {
+		repoBucket := tx.Bucket(repositoriesBucket).Bucket([]byte(refName))
+		if repoBucket == nil {
+			return nil
+		}
+		return repoBucket.ForEach(func(refStrKey, idRaw []byte) error {
+			named, err := reference.ParseNormalizedNamed(string(refStrKey))
+			if err != nil {
+				// Should never happen.
+				return nil
+			}
+			associations = append(associations, Association{
+				Ref: named,
+				ID:  digest.Digest(idRaw),
+			})
+			return nil
+		})
+	})
+	if err != nil || associations == nil {
+		return nil
+	}
+
+	sort.Sort(lexicalAssociations(associations))
+	return associations
+}
+
+// Resolve disambiguates refOrID the same way refStore.Resolve does, using
+// the warm referencesByIDCache for the name-and-tag and short-ID-prefix
+// fallbacks instead of scanning bolt buckets.
+func (s *boltStore) Resolve(refOrID string) (digest.Digest, reference.Named, error) {
+	if id, err := digest.Parse(refOrID); err == nil {
+		s.mu.RLock()
+		_, exists := s.referencesByIDCache[id]
+		s.mu.RUnlock()
+		if exists {
+			return id, nil, nil
+		}
+	}
+
+	if ref, err := reference.ParseAnyReference(refOrID); err == nil {
+		if canonical, ok := ref.(reference.Canonical); ok {
+			if id, err := s.Get(canonical); err == nil {
+				return id, canonical, nil
+			}
+		}
+
+		if named, err := reference.ParseNormalizedNamed(refOrID); err == nil {
+			if id, err := s.Get(named); err == nil {
+				return id, named, nil
+			}
+
+			if tagged, ok := named.(reference.NamedTagged); ok {
+				if id, matched, ok := s.lookupByNameAndTag(tagged); ok {
+					return id, matched, nil
+				}
+			}
+		}
+	}
+
+	return s.resolveIDPrefix(refOrID)
+}
+
+func (s *boltStore) lookupByNameAndTag(tagged reference.NamedTagged) (digest.Digest, reference.Named, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	name := reference.FamiliarName(tagged)
+	for id, refs := range s.referencesByIDCache {
+		for _, candidate := range refs {
+			candidateTagged, ok := candidate.(reference.NamedTagged)
+			if ok && reference.FamiliarName(candidateTagged) == name && candidateTagged.Tag() == tagged.Tag() {
+				return id, candidate, true
+			}
+		}
+	}
+	return "", nil, false
+}
+
+func (s *boltStore) resolveIDPrefix(prefix string) (digest.Digest, reference.Named, error) {
+	if prefix == "" {
+		return "", nil, ErrDoesNotExist
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var match digest.Digest
+	for id := range s.referencesByIDCache {
+		if strings.HasPrefix(id.Encoded(), prefix) || strings.HasPrefix(id.String(), prefix) {
+			if match != "" && match != id {
+				return "", nil, errors.WithStack(ErrAmbiguous)
+			}
+			match = id
+		}
+	}
+
+	if match == "" {
+		return "", nil, ErrDoesNotExist
+	}
+	return match, nil, nil
+}
+
+// indexRef records that refStr (belonging to repository bucket refName)
+// points at id, in the idsBucket secondary index.
+func indexRef(tx *bolt.Tx, id digest.Digest, refStr string) error {
+	idBucket, err := tx.Bucket(idsBucket).CreateBucketIfNotExists([]byte(id.String()))
+	if err != nil {
+		return err
+	}
+	return idBucket.Put([]byte(refStr), []byte{})
+}
+
+// unindexRef removes refStr from id's entry in the idsBucket secondary
+// index, dropping the id's bucket entirely once it is empty.
+func unindexRef(tx *bolt.Tx, id digest.Digest, refStr string) error {
+	idsRoot := tx.Bucket(idsBucket)
+	idBucket := idsRoot.Bucket([]byte(id.String()))
+	if idBucket == nil {
+		return nil
+	}
+	if err := idBucket.Delete([]byte(refStr)); err != nil {
+		return err
+	}
+	if isEmpty(idBucket) {
+		return idsRoot.DeleteBucket([]byte(id.String()))
+	}
+	return nil
+}
+
+func isEmpty(b *bolt.Bucket) bool {
+	k, _ := b.Cursor().First()
+	return k == nil
+}
+
+// jsonRepositories mirrors the on-disk shape refStore.save produces, so
+// migrateFromJSON can decode an existing repositories.json without
+// depending on refStore itself.
+type jsonRepositories struct {
+	Repositories map[string]repository
+}
+
+// migrateFromJSON imports an existing JSON-file store found at jsonPath,
+// if any, exactly once: it checks a marker in metaBucket before doing any
+// work and sets it once the import has succeeded, so a later restart
+// cannot re-import it; jsonPath is then renamed aside. Without the
+// marker, every restart would replay the
+// JSON file's contents with force=true, resurrecting tags deleted from
+// bolt and reverting tags repointed in bolt back to the JSON file's
+// stale image IDs.
+func (s *boltStore) migrateFromJSON(jsonPath string) error {
+	migrated, err := s.migratedFromJSON()
+	if err != nil {
+		return err
+	}
+	if migrated {
+		return nil
+	}
+
+	f, err := os.Open(jsonPath)
+	if os.IsNotExist(err) {
+		return s.markMigratedFromJSON()
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var data jsonRepositories
+	if err := json.NewDecoder(f).Decode(&data); err != nil {
+		return err
+	}
+
+	for refName, repo := range data.Repositories {
+		for refStr, id := range repo {
+			ref, err := reference.ParseNormalizedNamed(refStr)
+			if err != nil {
+				continue
+			}
+			if err := s.addReference(ref, id, true); err != nil {
+				return errors.Wrapf(err, "failed to migrate %s", refName)
+			}
+		}
+	}
+
+	if err := s.markMigratedFromJSON(); err != nil {
+		return err
+	}
+
+	// Move the JSON store aside. Only the migration marker above is load
+	// bearing; this rename keeps the now-unused file from lingering and
+	// being mistaken for the store still in use. A failed rename is
+	// returned as an error, although the marker has already been set.
+	if err := os.Rename(jsonPath, jsonPath+".migrated"); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to archive migrated JSON reference store")
+	}
+
+	return nil
+}
+
+func (s *boltStore) migratedFromJSON() (bool, error) {
+	var migrated bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		migrated = tx.Bucket(metaBucket).Get(migratedFromJSONKey) != nil
+		return nil
+	})
+	return migrated, err
+}
+
+func (s *boltStore) markMigratedFromJSON() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(metaBucket).Put(migratedFromJSONKey, []byte{1})
+	})
+}